@@ -0,0 +1,322 @@
+package endpoints
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws/endpoints/rulesfn"
+)
+
+// RuleEndpoint is the result of resolving a service's EndpointParameters
+// against its rule tree.
+type RuleEndpoint struct {
+	URL        string
+	Properties map[string]interface{}
+	Headers    map[string][]string
+}
+
+// EndpointParameters is implemented by the per-service, code generated
+// parameter types a rule-set resolver accepts. AsRuleScope converts the
+// concrete parameters into the variable scope rule conditions and
+// templated strings (e.g. "{Region}") are evaluated against.
+type EndpointParameters interface {
+	AsRuleScope() map[string]interface{}
+}
+
+// RuleCondition is one entry of a rule's "conditions" list in the source
+// rule-set document.
+type RuleCondition struct {
+	Fn     string
+	Argv   []interface{}
+	Assign string
+}
+
+// Rule is one node of a service's rule tree, decoded from the rule-set
+// document's "rules" block. Exactly one of Rules, Endpoint, or Error is
+// populated, selected by Type. Generated per-service resolvers hold their
+// rule tree as a literal []Rule and hand it to ResolveEndpoint rather than
+// re-implementing the tree walk themselves.
+type Rule struct {
+	Conditions []RuleCondition
+	Type       string // "tree", "endpoint", or "error"
+	Rules      []Rule
+	Endpoint   *RuleEndpoint
+	Error      string
+}
+
+// ResolveEndpoint walks rules, evaluating each node's conditions against
+// scope until an endpoint or error rule matches. It is the interpreter
+// shared by every generated per-service resolver, so a new condition
+// function only needs to be taught to callRuleFn once.
+func ResolveEndpoint(rules []Rule, scope map[string]interface{}) (RuleEndpoint, error) {
+	for _, r := range rules {
+		// Each rule evaluates its conditions against its own copy of scope,
+		// so a condition Assign-ed partway through a rule that ultimately
+		// doesn't match never leaks into its siblings' evaluation.
+		ruleScope := cloneScope(scope)
+
+		matched, err := evalConditions(r.Conditions, ruleScope)
+		if err != nil {
+			return RuleEndpoint{}, err
+		}
+		if !matched {
+			continue
+		}
+
+		switch r.Type {
+		case "tree":
+			return ResolveEndpoint(r.Rules, ruleScope)
+		case "endpoint":
+			return RuleEndpoint{
+				URL:        evalTemplate(r.Endpoint.URL, ruleScope),
+				Properties: r.Endpoint.Properties,
+				Headers:    r.Endpoint.Headers,
+			}, nil
+		case "error":
+			return RuleEndpoint{}, fmt.Errorf("%s", evalTemplate(r.Error, ruleScope))
+		default:
+			return RuleEndpoint{}, fmt.Errorf("rule engine: unknown rule type %q", r.Type)
+		}
+	}
+
+	return RuleEndpoint{}, fmt.Errorf("rule engine: no rule matched the provided parameters")
+}
+
+// cloneScope returns a shallow copy of scope so a rule's Assign-ed
+// condition results can be discarded along with the rule itself.
+func cloneScope(scope map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(scope))
+	for k, v := range scope {
+		out[k] = v
+	}
+	return out
+}
+
+// evalConditions evaluates a rule's conditions in order against scope,
+// short circuiting as soon as one does not hold. A condition with Assign
+// set stores its result in scope under that name, making it available to
+// later conditions and the rule's templated endpoint or error string.
+//
+// A condition holds when its result is neither unset nor false. "Unset"
+// is checked with rulesfn.IsSet rather than a bare v == nil: a condition
+// like {"fn": "parseURL", ...} that fails returns a typed-nil *ParsedURL,
+// which a plain nil check would miss, matching the rule and assigning the
+// nil pointer into scope for a later {ref#field} to dereference.
+func evalConditions(conds []RuleCondition, scope map[string]interface{}) (bool, error) {
+	for _, c := range conds {
+		v, err := callRuleFn(c.Fn, c.Argv, scope)
+		if err != nil {
+			return false, err
+		}
+
+		if c.Assign != "" {
+			scope[c.Assign] = v
+		}
+
+		if !rulesfn.IsSet(v) {
+			return false, nil
+		}
+		if b, ok := v.(bool); ok && !b {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// callRuleFn evaluates a single rule function call, resolving its
+// arguments (literals, {"ref": name} scope lookups, or nested function
+// calls) before dispatching to the matching rulesfn implementation. An
+// argument that resolves to nil or the wrong type (e.g. a condition
+// referencing an EndpointParameters field the caller left unset) is
+// reported as an error rather than panicking the resolver.
+func callRuleFn(fn string, argv []interface{}, scope map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(argv))
+	for i, a := range argv {
+		v, err := resolveArg(a, scope)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	boolArg := func(i int) (bool, error) {
+		v, ok := args[i].(bool)
+		if !ok {
+			return false, fmt.Errorf("rule engine: %s argv[%d] is not a bool, got %T", fn, i, args[i])
+		}
+		return v, nil
+	}
+	stringArg := func(i int) (string, error) {
+		v, ok := args[i].(string)
+		if !ok {
+			return "", fmt.Errorf("rule engine: %s argv[%d] is not a string, got %T", fn, i, args[i])
+		}
+		return v, nil
+	}
+	intArg := func(i int) (int, error) {
+		n, err := strconv.Atoi(fmt.Sprint(args[i]))
+		if err != nil {
+			return 0, fmt.Errorf("rule engine: %s argv[%d] is not an int, got %v", fn, i, args[i])
+		}
+		return n, nil
+	}
+
+	switch fn {
+	case "isSet":
+		return rulesfn.IsSet(args[0]), nil
+	case "not":
+		v, err := boolArg(0)
+		return rulesfn.Not(v), err
+	case "booleanEquals":
+		v1, err := boolArg(0)
+		if err != nil {
+			return nil, err
+		}
+		v2, err := boolArg(1)
+		if err != nil {
+			return nil, err
+		}
+		return rulesfn.BooleanEquals(v1, v2), nil
+	case "stringEquals":
+		v1, err := stringArg(0)
+		if err != nil {
+			return nil, err
+		}
+		v2, err := stringArg(1)
+		if err != nil {
+			return nil, err
+		}
+		return rulesfn.StringEquals(v1, v2), nil
+	case "parseURL":
+		v, err := stringArg(0)
+		if err != nil {
+			return nil, err
+		}
+		return rulesfn.ParseURL(v), nil
+	case "substring":
+		s, err := stringArg(0)
+		if err != nil {
+			return nil, err
+		}
+		start, err := intArg(1)
+		if err != nil {
+			return nil, err
+		}
+		stop, err := intArg(2)
+		if err != nil {
+			return nil, err
+		}
+		reverse, err := boolArg(3)
+		if err != nil {
+			return nil, err
+		}
+		return rulesfn.Substring(s, start, stop, reverse), nil
+	case "uriEncode":
+		v, err := stringArg(0)
+		if err != nil {
+			return nil, err
+		}
+		return rulesfn.UriEncode(v), nil
+	case "isValidHostLabel":
+		v, err := stringArg(0)
+		if err != nil {
+			return nil, err
+		}
+		allowSubDomains, err := boolArg(1)
+		if err != nil {
+			return nil, err
+		}
+		return rulesfn.IsValidHostLabel(v, allowSubDomains), nil
+	case "aws.partition":
+		region, err := stringArg(0)
+		if err != nil {
+			return nil, err
+		}
+		result := rulesfn.AWSPartition(region)
+		return &result, nil
+	default:
+		return nil, &rulesfn.UnknownFunctionError{Name: fn}
+	}
+}
+
+// resolveArg resolves one condition argument: a literal value, a
+// {"ref": name} scope lookup, or a nested {"fn": ..., "argv": ...} call.
+func resolveArg(arg interface{}, scope map[string]interface{}) (interface{}, error) {
+	m, ok := arg.(map[string]interface{})
+	if !ok {
+		return arg, nil
+	}
+
+	if ref, ok := m["ref"].(string); ok {
+		return scope[ref], nil
+	}
+
+	if fn, ok := m["fn"].(string); ok {
+		argv, _ := m["argv"].([]interface{})
+		return callRuleFn(fn, argv, scope)
+	}
+
+	return arg, nil
+}
+
+// templateRefPattern matches the "{param}" and "{param#field}" references a
+// rule's endpoint URL, headers, and error strings may contain.
+var templateRefPattern = regexp.MustCompile(`\{([A-Za-z0-9_]+)(?:#([A-Za-z0-9_]+))?\}`)
+
+// evalTemplate substitutes every "{param}" or "{param#field}" reference in
+// tmpl with its value from scope. A referenced field is looked up via
+// fieldByName on a pointer-shaped scope value (e.g. *rulesfn.PartitionResult
+// or *rulesfn.ParsedURL); an unresolved reference is left empty.
+func evalTemplate(tmpl string, scope map[string]interface{}) string {
+	return templateRefPattern.ReplaceAllStringFunc(tmpl, func(ref string) string {
+		groups := templateRefPattern.FindStringSubmatch(ref)
+		param, field := groups[1], groups[2]
+
+		v, ok := scope[param]
+		if !ok || v == nil {
+			return ""
+		}
+		if field == "" {
+			if s, ok := v.(*string); ok && s != nil {
+				return *s
+			}
+			return fmt.Sprint(v)
+		}
+
+		return fieldByName(v, field)
+	})
+}
+
+// fieldByName returns the string representation of field on v, where v is
+// one of the pointer-shaped values callRuleFn assigns into scope
+// (*rulesfn.PartitionResult, *rulesfn.ParsedURL, ...).
+func fieldByName(v interface{}, field string) string {
+	switch t := v.(type) {
+	case *rulesfn.PartitionResult:
+		switch field {
+		case "name":
+			return t.Name
+		case "dnsSuffix":
+			return t.DNSSuffix
+		case "implicitGlobalRegion":
+			return t.ImplicitGlobalRegion
+		}
+	case *rulesfn.ParsedURL:
+		switch field {
+		case "scheme":
+			return t.Scheme
+		case "authority":
+			return t.Authority
+		case "path":
+			return t.Path
+		case "normalizedPath":
+			return t.NormalizedPath
+		case "isIp":
+			return strconv.FormatBool(t.IsIp)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,111 @@
+package endpoints
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/endpoints/rulesfn"
+)
+
+// TestResolveEndpoint_BareParseURLConditionFailure is a regression test for
+// a rule whose truthiness comes directly from a condition function's
+// result (no isSet wrapper) rather than a boolean. parseURL returning a
+// typed-nil *ParsedURL on an unparsable input must still be treated as
+// "unset" here, the same as it is when wrapped in isSet(...) — otherwise
+// the rule wrongly matches, assigns the nil pointer into scope, and a
+// later {ref#field} reference in its endpoint or error string panics.
+func TestResolveEndpoint_BareParseURLConditionFailure(t *testing.T) {
+	rules := []Rule{
+		{
+			Conditions: []RuleCondition{
+				{Fn: "parseURL", Argv: []interface{}{map[string]interface{}{"ref": "Endpoint"}}, Assign: "url"},
+			},
+			Type:     "endpoint",
+			Endpoint: &RuleEndpoint{URL: "https://{url#authority}/"},
+		},
+		{
+			Type:     "endpoint",
+			Endpoint: &RuleEndpoint{URL: "fallback"},
+		},
+	}
+
+	scope := map[string]interface{}{"Endpoint": "not a valid url::://"}
+	res, err := ResolveEndpoint(rules, scope)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if res.URL != "fallback" {
+		t.Errorf("expect the fallback rule to match on an unparsable URL, got %q", res.URL)
+	}
+}
+
+// TestResolveEndpoint_ScopeDoesNotLeakBetweenSiblings guards against an
+// Assign in a rule whose conditions ultimately don't match being visible
+// to a sibling rule evaluated afterward.
+func TestResolveEndpoint_ScopeDoesNotLeakBetweenSiblings(t *testing.T) {
+	rules := []Rule{
+		{
+			Conditions: []RuleCondition{
+				{Fn: "isSet", Argv: []interface{}{map[string]interface{}{"ref": "Region"}}, Assign: "leaked"},
+				{Fn: "stringEquals", Argv: []interface{}{map[string]interface{}{"ref": "Region"}, "never-matches"}},
+			},
+			Type:     "endpoint",
+			Endpoint: &RuleEndpoint{URL: "first"},
+		},
+		{
+			Conditions: []RuleCondition{
+				{Fn: "isSet", Argv: []interface{}{map[string]interface{}{"ref": "leaked"}}},
+			},
+			Type:  "error",
+			Error: "leaked should not be set here",
+		},
+		{
+			Type:     "endpoint",
+			Endpoint: &RuleEndpoint{URL: "fallback"},
+		},
+	}
+
+	scope := map[string]interface{}{"Region": "us-west-2"}
+	res, err := ResolveEndpoint(rules, scope)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if res.URL != "fallback" {
+		t.Errorf("expect the fallback rule to match, got %q", res.URL)
+	}
+}
+
+// TestCallRuleFn_InvalidArgumentType guards against a panic when a
+// condition's argument resolves to the wrong type for the function.
+func TestCallRuleFn_InvalidArgumentType(t *testing.T) {
+	_, err := callRuleFn("booleanEquals", []interface{}{"not-a-bool", true}, map[string]interface{}{})
+	if err == nil {
+		t.Fatalf("expect an error for a mistyped argument, got none")
+	}
+}
+
+func TestResolveEndpoint_UnknownFunction(t *testing.T) {
+	rules := []Rule{
+		{
+			Conditions: []RuleCondition{{Fn: "notARealFunction"}},
+			Type:       "endpoint",
+			Endpoint:   &RuleEndpoint{URL: "unreachable"},
+		},
+	}
+
+	if _, err := ResolveEndpoint(rules, map[string]interface{}{}); err == nil {
+		t.Fatalf("expect an error for an unknown rule function, got none")
+	}
+}
+
+func TestEvalTemplate(t *testing.T) {
+	scope := map[string]interface{}{
+		"Region": "us-west-2",
+		"parsed": rulesfn.ParseURL("https://example.com/a"),
+	}
+
+	got := evalTemplate("https://{Region}.{parsed#authority}/", scope)
+	want := "https://us-west-2.example.com/"
+	if got != want {
+		t.Errorf("expect %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,187 @@
+// Package rulesfn provides the built-in condition and templating functions
+// referenced by endpoint resolvers generated from an AWS endpoint
+// rule-set document (see aws/endpoints/v3model_rules_codegen.go). The
+// functions here are intentionally dependency-free so generated resolvers
+// can import this package without pulling in the rest of the SDK.
+package rulesfn
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// IsSet returns whether v holds a value, as opposed to being an unset
+// (nil) parameter or previously assigned condition result. v is checked
+// with reflect because a condition result like ParseURL's *ParsedURL is
+// frequently a nil pointer boxed into this interface{} argument — a plain
+// v != nil would report such a failed ParseURL/Substring as "set".
+func IsSet(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func:
+		return !rv.IsNil()
+	default:
+		return true
+	}
+}
+
+// BooleanEquals returns whether v1 and v2 are equal.
+func BooleanEquals(v1, v2 bool) bool {
+	return v1 == v2
+}
+
+// StringEquals returns whether v1 and v2 are equal.
+func StringEquals(v1, v2 string) bool {
+	return v1 == v2
+}
+
+// Not returns the inverse of v.
+func Not(v bool) bool {
+	return !v
+}
+
+// ParsedURL is the result of parsing a URL with ParseURL.
+type ParsedURL struct {
+	Scheme         string
+	Authority      string
+	Path           string
+	NormalizedPath string
+	IsIp           bool
+}
+
+// ParseURL parses v as a URL. It returns nil, rather than an error, when v
+// is not a valid URL so it can be used directly as an isSet condition
+// argument in generated rules.
+func ParseURL(v string) *ParsedURL {
+	u, err := url.Parse(v)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	path := u.Path
+	normalizedPath := path
+	if !strings.HasSuffix(normalizedPath, "/") {
+		normalizedPath += "/"
+	}
+
+	return &ParsedURL{
+		Scheme:         u.Scheme,
+		Authority:      u.Host,
+		Path:           path,
+		NormalizedPath: normalizedPath,
+		IsIp:           net.ParseIP(u.Hostname()) != nil,
+	}
+}
+
+// Substring returns the substring of v from start (inclusive) to stop
+// (exclusive). reverse indexes start/stop from the end of v. It returns nil
+// if the requested range is out of bounds, matching the rule engine's
+// treatment of an invalid substring as "not set".
+func Substring(v string, start, stop int, reverse bool) *string {
+	if reverse {
+		start, stop = len(v)-stop, len(v)-start
+	}
+	if start < 0 || stop > len(v) || start >= stop {
+		return nil
+	}
+
+	out := v[start:stop]
+	return &out
+}
+
+// PartitionResult is the outcome of resolving a region to its partition via
+// AWSPartition, exposed to generated rules as `{PartitionResult#field}`.
+type PartitionResult struct {
+	Name                 string
+	DNSSuffix            string
+	SupportsFIPS         bool
+	SupportsDualStack    bool
+	ImplicitGlobalRegion string
+}
+
+// defaultPartitions is the minimal built-in partition table used to resolve
+// aws.partition(region) when the generated resolver does not bundle its
+// own. It mirrors the handful of partitions the SDK ships by default.
+var defaultPartitions = []struct {
+	regionPrefix string
+	result       PartitionResult
+}{
+	{regionPrefix: "cn-", result: PartitionResult{Name: "aws-cn", DNSSuffix: "amazonaws.com.cn", SupportsFIPS: true, SupportsDualStack: true, ImplicitGlobalRegion: "cn-northwest-1"}},
+	{regionPrefix: "us-gov-", result: PartitionResult{Name: "aws-us-gov", DNSSuffix: "amazonaws.com", SupportsFIPS: true, SupportsDualStack: true, ImplicitGlobalRegion: "us-gov-west-1"}},
+	{regionPrefix: "us-iso-", result: PartitionResult{Name: "aws-iso", DNSSuffix: "c2s.ic.gov", SupportsFIPS: true, SupportsDualStack: false, ImplicitGlobalRegion: "us-iso-east-1"}},
+	{regionPrefix: "us-isob-", result: PartitionResult{Name: "aws-iso-b", DNSSuffix: "sc2s.sgov.gov", SupportsFIPS: true, SupportsDualStack: false, ImplicitGlobalRegion: "us-isob-east-1"}},
+}
+
+var awsPartition = PartitionResult{
+	Name: "aws", DNSSuffix: "amazonaws.com", SupportsFIPS: true, SupportsDualStack: true, ImplicitGlobalRegion: "us-east-1",
+}
+
+// AWSPartition resolves region to the partition it belongs to, implementing
+// the rule set's `aws.partition` function. Unrecognized regions fall back
+// to the standard "aws" partition, consistent with the rule engine treating
+// an unbounded region as belonging to its home partition.
+func AWSPartition(region string) PartitionResult {
+	for _, p := range defaultPartitions {
+		if strings.HasPrefix(region, p.regionPrefix) {
+			return p.result
+		}
+	}
+
+	return awsPartition
+}
+
+// UriEncode percent-encodes v for safe inclusion in a URI path segment.
+func UriEncode(v string) string {
+	return strings.ReplaceAll(url.QueryEscape(v), "+", "%20")
+}
+
+// IsValidHostLabel returns whether v is a valid DNS host label. When
+// allowSubDomains is true, v may be a dot separated sequence of labels.
+func IsValidHostLabel(v string, allowSubDomains bool) bool {
+	labels := []string{v}
+	if allowSubDomains {
+		labels = strings.Split(v, ".")
+	}
+
+	for _, label := range labels {
+		if !isValidHostLabel(label) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isValidHostLabel(v string) bool {
+	if len(v) == 0 || len(v) > 63 {
+		return false
+	}
+
+	for i, c := range v {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' && i != 0:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// UnknownFunctionError is returned by a generated resolver when a rule
+// references a condition function this package does not implement.
+type UnknownFunctionError struct {
+	Name string
+}
+
+func (e *UnknownFunctionError) Error() string {
+	return fmt.Sprintf("rulesfn: unknown rule function %q", e.Name)
+}
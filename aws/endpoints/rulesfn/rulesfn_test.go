@@ -0,0 +1,90 @@
+package rulesfn
+
+import "testing"
+
+func TestIsSet(t *testing.T) {
+	set := "value"
+
+	cases := map[string]struct {
+		v      interface{}
+		expect bool
+	}{
+		"nil interface":              {v: nil, expect: false},
+		"typed-nil *ParsedURL":       {v: ParseURL("not a valid url::://"), expect: false},
+		"typed-nil *string":          {v: Substring("ab", 0, 5, false), expect: false},
+		"non-nil *ParsedURL":         {v: ParseURL("https://example.com/path"), expect: true},
+		"non-nil *string":            {v: &set, expect: true},
+		"false boolean is still set": {v: false, expect: true},
+		"zero-value string is set":   {v: "", expect: true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := IsSet(c.v); got != c.expect {
+				t.Errorf("expect %t, got %t", c.expect, got)
+			}
+		})
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	if u := ParseURL("not a valid url::://"); u != nil {
+		t.Errorf("expect nil for an unparsable URL, got %+v", u)
+	}
+
+	u := ParseURL("https://example.com/a/b")
+	if u == nil {
+		t.Fatalf("expect a parsed URL, got nil")
+	}
+	if e, a := "https", u.Scheme; e != a {
+		t.Errorf("expect scheme %q, got %q", e, a)
+	}
+	if e, a := "example.com", u.Authority; e != a {
+		t.Errorf("expect authority %q, got %q", e, a)
+	}
+	if e, a := "/a/b/", u.NormalizedPath; e != a {
+		t.Errorf("expect normalized path %q, got %q", e, a)
+	}
+}
+
+func TestAWSPartition(t *testing.T) {
+	cases := map[string]struct {
+		region string
+		expect string
+	}{
+		"standard":                            {region: "us-west-2", expect: "aws"},
+		"china":                               {region: "cn-north-1", expect: "aws-cn"},
+		"us-gov":                              {region: "us-gov-west-1", expect: "aws-us-gov"},
+		"unrecognized falls back to standard": {region: "not-a-real-region-1", expect: "aws"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := AWSPartition(c.region).Name; got != c.expect {
+				t.Errorf("expect partition %q, got %q", c.expect, got)
+			}
+		})
+	}
+}
+
+func TestIsValidHostLabel(t *testing.T) {
+	cases := map[string]struct {
+		v               string
+		allowSubDomains bool
+		expect          bool
+	}{
+		"valid label":                     {v: "bucket-1", expect: true},
+		"empty":                           {v: "", expect: false},
+		"underscore invalid":              {v: "bucket_1", expect: false},
+		"dot rejected without subdomains": {v: "a.b", allowSubDomains: false, expect: false},
+		"dot allowed with subdomains":     {v: "a.b", allowSubDomains: true, expect: true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := IsValidHostLabel(c.v, c.allowSubDomains); got != c.expect {
+				t.Errorf("expect %t, got %t", c.expect, got)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@
 package endpoints
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"reflect"
@@ -21,6 +22,18 @@ type CodeGenOptions struct {
 	// Disables code generation of the service endpoint prefix IDs defined in
 	// the model.
 	DisableGenerateServiceIDs bool
+
+	// The endpoint-tests.json model file the generated resolver will be
+	// verified against. When nil, no test suite is generated.
+	TestsInput io.Reader
+
+	// The destination the generated endpoint resolution test suite will be
+	// written to. Required when TestsInput is set.
+	TestsOutput io.Writer
+
+	// Disables code generation of the endpoint resolution test suite, even
+	// when TestsInput and TestsOutput are both provided.
+	DisableGenerateTests bool
 }
 
 // Set combines all of the option functions together
@@ -57,9 +70,73 @@ func CodeGenModel(modelFile io.Reader, outFile io.Writer, optFns ...func(*CodeGe
 		return fmt.Errorf("failed to execute template, %v", err)
 	}
 
+	if opts.TestsInput == nil || opts.DisableGenerateTests {
+		return nil
+	}
+	if opts.TestsOutput == nil {
+		return fmt.Errorf("TestsOutput must be provided when TestsInput is set")
+	}
+
+	fixtures, err := decodeEndpointTestFixtures(opts.TestsInput)
+	if err != nil {
+		return err
+	}
+
+	if err := tmpl.ExecuteTemplate(opts.TestsOutput, "tests", fixtures.TestCases); err != nil {
+		return fmt.Errorf("failed to execute tests template, %v", err)
+	}
+
 	return nil
 }
 
+// endpointTestFixtures is the root of an AWS endpoint-tests.json model file.
+type endpointTestFixtures struct {
+	TestCases []endpointTestCase `json:"testCases"`
+}
+
+// endpointTestCase describes a single (service, region, options) tuple to
+// resolve and the values the result is expected to match.
+type endpointTestCase struct {
+	Documentation string                 `json:"documentation"`
+	Service       string                 `json:"service"`
+	Region        string                 `json:"region"`
+	Options       endpointTestCaseOption `json:"options"`
+	Expect        endpointTestExpect     `json:"expect"`
+}
+
+// endpointTestCaseOption are the resolver Options the test case's resolution
+// should be performed with.
+type endpointTestCaseOption struct {
+	FIPS      bool `json:"fips"`
+	DualStack bool `json:"dualStack"`
+
+	// UseGlobalEndpoint is decoded from the fixture but not yet applied to
+	// the generated test below — this package's Options has no field for
+	// it yet. A fixture setting this is flagged with a TODO in the
+	// generated test rather than silently asserting default behavior.
+	UseGlobalEndpoint bool `json:"useGlobalEndpoint"`
+}
+
+// endpointTestExpect is the value the resolved endpoint, or the resolution
+// error, must match for the test case to pass. Error is mutually exclusive
+// with the other fields.
+type endpointTestExpect struct {
+	Hostname      string `json:"hostname"`
+	SigningRegion string `json:"signingRegion"`
+	SigningName   string `json:"signingName"`
+	Error         string `json:"error"`
+}
+
+// decodeEndpointTestFixtures unmarshals an endpoint-tests.json model file.
+func decodeEndpointTestFixtures(r io.Reader) (endpointTestFixtures, error) {
+	var fixtures endpointTestFixtures
+	if err := json.NewDecoder(r).Decode(&fixtures); err != nil {
+		return endpointTestFixtures{}, fmt.Errorf("failed to decode endpoint tests model, %v", err)
+	}
+
+	return fixtures, nil
+}
+
 func toSymbol(v string) string {
 	out := []rune{}
 	for _, c := range strings.Title(v) {
@@ -155,25 +232,40 @@ func serviceSet(ps partitions) map[string]struct{} {
 	return set
 }
 
+// variantSymbols maps each known endpointVariant bit to the Go symbol used
+// to reference it in generated code. endpointVariantSetter encodes a
+// variant bitmask by iterating this table, so appending an entry here is
+// all code generation needs to emit a new variant (e.g. an ipv6 or GovCloud
+// variant). The table is only built with the codegen tag: the runtime
+// resolver does not read it, and the new variant's bit constant itself is
+// not declared here.
+var variantSymbols = []struct {
+	Bit    endpointVariant
+	Symbol string
+}{
+	{Bit: fipsVariant, Symbol: "fipsVariant"},
+	{Bit: dualStackVariant, Symbol: "dualStackVariant"},
+}
+
 func endpointVariantSetter(variant endpointVariant) (string, error) {
 	if variant == 0 {
 		return "0", nil
 	}
 
-	if variant > (fipsVariant | dualStackVariant) {
-		return "", fmt.Errorf("unknown endpoint variant")
-	}
-
+	var known endpointVariant
 	var symbols []string
-	if variant&fipsVariant != 0 {
-		symbols = append(symbols, "fipsVariant")
+	for _, v := range variantSymbols {
+		known |= v.Bit
+		if variant&v.Bit != 0 {
+			symbols = append(symbols, v.Symbol)
+		}
 	}
-	if variant&dualStackVariant != 0 {
-		symbols = append(symbols, "dualStackVariant")
+
+	if variant&^known != 0 {
+		return "", fmt.Errorf("unknown endpoint variant")
 	}
-	v := strings.Join(symbols, "|")
 
-	return v, nil
+	return strings.Join(symbols, "|"), nil
 }
 
 func endpointKeySetter(e endpointKey) (string, error) {
@@ -205,6 +297,12 @@ func defaultKeySetter(e defaultKey) (string, error) {
 	return sb.String(), nil
 }
 
+// testCaseFuncName derives a unique, exported test function name for a test
+// case's position in the fixture file.
+func testCaseFuncName(i int, tc endpointTestCase) string {
+	return fmt.Sprintf("%s_%s_%d", toSymbol(tc.Service), toSymbol(tc.Region), i)
+}
+
 var funcMap = template.FuncMap{
 	"ToSymbol":              toSymbol,
 	"QuoteString":           quoteString,
@@ -220,6 +318,7 @@ var funcMap = template.FuncMap{
 	"EndpointVariantSetter": endpointVariantSetter,
 	"EndpointKeySetter":     endpointKeySetter,
 	"DefaultKeySetter":      defaultKeySetter,
+	"TestCaseFuncName":      testCaseFuncName,
 }
 
 const v3Tmpl = `
@@ -409,4 +508,56 @@ endpoint{
 	{{ BoxedBoolIfSet "Deprecated: %s,\n" .Deprecated -}}
 }
 {{- end }}
+
+{{ define "tests" -}}
+// Code generated by aws/endpoints/v3model_codegen.go. DO NOT EDIT.
+
+package endpoints
+
+import (
+	"strings"
+	"testing"
+)
+
+{{ range $i, $tc := . }}
+	{{ if $tc.Documentation -}}
+	// {{ $tc.Documentation }}
+	{{ end -}}
+	{{ if $tc.Options.UseGlobalEndpoint -}}
+	// TODO: this fixture sets useGlobalEndpoint, but Options has no field
+	// for it yet, so the case below runs without it applied.
+	{{ end -}}
+	func TestDefaultResolver_{{ TestCaseFuncName $i $tc }}(t *testing.T) {
+		{{ if $tc.Expect.Error }}_{{ else }}resolved{{ end }}, err := DefaultResolver().EndpointFor({{ QuoteString $tc.Service }}, {{ QuoteString $tc.Region }}, func(o *Options) {
+			o.UseDualStackEndpoint = {{ if $tc.Options.DualStack }}DualStackEndpointStateEnabled{{ else }}DualStackEndpointStateDisabled{{ end }}
+			o.UseFIPSEndpoint = {{ if $tc.Options.FIPS }}FIPSEndpointStateEnabled{{ else }}FIPSEndpointStateDisabled{{ end }}
+			o.ResolveUnknownService = true
+		})
+
+		{{ if $tc.Expect.Error -}}
+		if err == nil {
+			t.Fatalf("expect error resolving endpoint, got none")
+		}
+		if e, a := {{ QuoteString $tc.Expect.Error }}, err.Error(); !strings.Contains(a, e) {
+			t.Errorf("expect error to contain %q, got %q", e, a)
+		}
+		{{ else -}}
+		if err != nil {
+			t.Fatalf("expect no error resolving endpoint, got %v", err)
+		}
+		{{ if $tc.Expect.Hostname -}}
+		if e, a := {{ QuoteString $tc.Expect.Hostname }}, resolved.URL; !strings.Contains(a, e) {
+			t.Errorf("expect resolved endpoint to contain %q, got %q", e, a)
+		}
+		{{ end -}}
+		if e, a := {{ QuoteString $tc.Expect.SigningRegion }}, resolved.SigningRegion; len(e) > 0 && e != a {
+			t.Errorf("expect signing region %q, got %q", e, a)
+		}
+		if e, a := {{ QuoteString $tc.Expect.SigningName }}, resolved.SigningName; len(e) > 0 && e != a {
+			t.Errorf("expect signing name %q, got %q", e, a)
+		}
+		{{ end -}}
+	}
+{{ end -}}
+{{- end }}
 `
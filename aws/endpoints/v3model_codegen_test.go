@@ -0,0 +1,130 @@
+//go:build codegen
+// +build codegen
+
+package endpoints
+
+import "testing"
+
+// testThirdVariant is a synthetic endpointVariant bit with no corresponding
+// entry in variantSymbols until withTestVariant registers one, exercising
+// the "append one line" extensibility variantSymbols is meant to provide.
+const testThirdVariant endpointVariant = 1 << 2
+
+// withTestVariant registers testThirdVariant in variantSymbols for the
+// duration of a test, restoring the original table on cleanup so other
+// tests keep seeing only the real fips/dualStack variants.
+func withTestVariant(t *testing.T) {
+	t.Helper()
+
+	orig := variantSymbols
+	variantSymbols = append(append([]struct {
+		Bit    endpointVariant
+		Symbol string
+	}{}, orig...), struct {
+		Bit    endpointVariant
+		Symbol string
+	}{Bit: testThirdVariant, Symbol: "testThirdVariant"})
+
+	t.Cleanup(func() { variantSymbols = orig })
+}
+
+func TestEndpointVariantSetter(t *testing.T) {
+	withTestVariant(t)
+
+	cases := map[string]struct {
+		variant endpointVariant
+		expect  string
+		wantErr bool
+	}{
+		"no variant": {
+			variant: 0,
+			expect:  "0",
+		},
+		"fips only": {
+			variant: fipsVariant,
+			expect:  "fipsVariant",
+		},
+		"dual-stack only": {
+			variant: dualStackVariant,
+			expect:  "dualStackVariant",
+		},
+		"fips and dual-stack": {
+			variant: fipsVariant | dualStackVariant,
+			expect:  "fipsVariant|dualStackVariant",
+		},
+		"synthetic third variant alone": {
+			variant: testThirdVariant,
+			expect:  "testThirdVariant",
+		},
+		"fips and synthetic third variant": {
+			variant: fipsVariant | testThirdVariant,
+			expect:  "fipsVariant|testThirdVariant",
+		},
+		"all three variants": {
+			variant: fipsVariant | dualStackVariant | testThirdVariant,
+			expect:  "fipsVariant|dualStackVariant|testThirdVariant",
+		},
+		"unregistered bit": {
+			variant: 1 << 5,
+			wantErr: true,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			got, err := endpointVariantSetter(c.variant)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("expect error for variant %d, got none", c.variant)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expect no error for variant %d, got %v", c.variant, err)
+			}
+			if got != c.expect {
+				t.Errorf("expect %q, got %q", c.expect, got)
+			}
+		})
+	}
+}
+
+// TestEndpointKeySetter_SyntheticVariant checks the Go source the codegen
+// setter emits for an endpointKey carrying a synthetic third variant. The
+// key is built directly rather than decoded from a model, and the emitted
+// source is compared as text, not compiled or looked up by a resolver.
+func TestEndpointKeySetter_SyntheticVariant(t *testing.T) {
+	withTestVariant(t)
+
+	key := endpointKey{Region: "mock-region-1", Variant: fipsVariant | testThirdVariant}
+
+	got, err := endpointKeySetter(key)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	const want = `endpointKey{
+Region: "mock-region-1",
+Variant: fipsVariant|testThirdVariant,
+}`
+	if got != want {
+		t.Errorf("expect %q, got %q", want, got)
+	}
+}
+
+func TestDefaultKeySetter_SyntheticVariant(t *testing.T) {
+	withTestVariant(t)
+
+	got, err := defaultKeySetter(defaultKey{Variant: testThirdVariant})
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+
+	const want = `defaultKey{
+Variant: testThirdVariant,
+}`
+	if got != want {
+		t.Errorf("expect %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,298 @@
+//go:build codegen
+// +build codegen
+
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// RulesCodeGenOptions are the options for generating a per-service endpoint
+// resolver from an AWS endpoint rule-set document (endpoint-rule-set.json).
+type RulesCodeGenOptions struct {
+	// The Go package the generated resolver belongs to, e.g. the service
+	// package importing aws/endpoints. Defaults to "endpoints".
+	PackageName string
+}
+
+// Set combines all of the option functions together
+func (d *RulesCodeGenOptions) Set(optFns ...func(*RulesCodeGenOptions)) {
+	for _, fn := range optFns {
+		fn(d)
+	}
+}
+
+// CodeGenRules given an AWS endpoint rule-set model file will decode it and
+// generate a Go source file implementing EndpointParameters and its rule
+// tree for the service. Unlike CodeGenModel's flat region lookup tables,
+// the generated resolver delegates to the shared tree interpreter in
+// ruleengine.go, which is how AWS's other SDKs express per-request
+// endpoint quirks (e.g. S3 Accelerate, MRAP, S3 Object Lambda) that a
+// static endpoints.json cannot.
+func CodeGenRules(modelFile io.Reader, outFile io.Writer, optFns ...func(*RulesCodeGenOptions)) error {
+	var opts RulesCodeGenOptions
+	opts.Set(optFns...)
+	if opts.PackageName == "" {
+		opts.PackageName = "endpoints"
+	}
+
+	var doc ruleSetDocument
+	if err := json.NewDecoder(modelFile).Decode(&doc); err != nil {
+		return fmt.Errorf("failed to decode endpoint rule-set model, %v", err)
+	}
+
+	v := struct {
+		ruleSetDocument
+		RulesCodeGenOptions
+	}{
+		ruleSetDocument:     doc,
+		RulesCodeGenOptions: opts,
+	}
+
+	tmpl := template.Must(template.New("tmpl").Funcs(rulesFuncMap).Parse(rulesTmpl))
+	if err := tmpl.ExecuteTemplate(outFile, "rules resolver", v); err != nil {
+		return fmt.Errorf("failed to execute rules template, %v", err)
+	}
+
+	return nil
+}
+
+// ruleSetDocument is the root of an AWS endpoint-rule-set.json model file.
+type ruleSetDocument struct {
+	Version    string                      `json:"version"`
+	ServiceID  string                      `json:"serviceId"`
+	Parameters map[string]ruleSetParameter `json:"parameters"`
+	Rules      []ruleDocument              `json:"rules"`
+}
+
+// ruleSetParameter describes one entry of the rule-set's "parameters"
+// block, from which EndpointParameters fields are derived.
+type ruleSetParameter struct {
+	Type          string      `json:"type"` // "String" or "Boolean"
+	BuiltIn       string      `json:"builtIn"`
+	Required      bool        `json:"required"`
+	Default       interface{} `json:"default"`
+	Documentation string      `json:"documentation"`
+}
+
+// ruleDocument is one node of the rule-set's "rules" tree.
+type ruleDocument struct {
+	Conditions    []conditionDocument `json:"conditions"`
+	Type          string              `json:"type"`
+	Rules         []ruleDocument      `json:"rules"`
+	Endpoint      *endpointDocument   `json:"endpoint"`
+	Error         string              `json:"error"`
+	Documentation string              `json:"documentation"`
+}
+
+// conditionDocument is one entry of a rule's "conditions" list. Argv
+// elements are left as interface{} since they may be literals, {"ref": ...}
+// scope lookups, or nested {"fn": ..., "argv": ...} calls.
+type conditionDocument struct {
+	Fn     string        `json:"fn"`
+	Argv   []interface{} `json:"argv"`
+	Assign string        `json:"assign"`
+}
+
+// endpointDocument is the "endpoint" payload of a rule whose Type is
+// "endpoint".
+type endpointDocument struct {
+	URL        string                 `json:"url"`
+	Properties map[string]interface{} `json:"properties"`
+	Headers    map[string][]string    `json:"headers"`
+}
+
+// rulesHasDefault reports whether a rule-set parameter declares a default
+// value. It exists because the default itself may be the zero value (e.g.
+// `false`), which a bare `{{ if $p.Default }}` would mistake for "no
+// default" in the template.
+func rulesHasDefault(p ruleSetParameter) bool {
+	return p.Default != nil
+}
+
+// rulesGoParamType returns the Go type used for an EndpointParameters field
+// decoded from a rule-set parameter.
+func rulesGoParamType(p ruleSetParameter) string {
+	switch p.Type {
+	case "Boolean":
+		return "*bool"
+	default:
+		return "*string"
+	}
+}
+
+// rulesGoLiteral renders v, a decoded JSON value appearing as a condition
+// argv element or an endpoint property, as Go source. JSON objects,
+// including the {"ref": ...} and {"fn": ...} forms the rule engine resolves
+// at runtime, become map[string]interface{} literals with sorted keys;
+// everything else is a plain scalar or slice literal.
+func rulesGoLiteral(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "nil", nil
+	case bool:
+		return fmt.Sprintf("%t", t), nil
+	case string:
+		return quoteString(t), nil
+	case float64:
+		return fmt.Sprintf("%v", t), nil
+	case []string:
+		var sb strings.Builder
+		sb.WriteString("[]string{")
+		for _, e := range t {
+			sb.WriteString(quoteString(e))
+			sb.WriteString(", ")
+		}
+		sb.WriteString("}")
+		return sb.String(), nil
+	case []interface{}:
+		var sb strings.Builder
+		sb.WriteString("[]interface{}{")
+		for _, e := range t {
+			lit, err := rulesGoLiteral(e)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(lit)
+			sb.WriteString(", ")
+		}
+		sb.WriteString("}")
+		return sb.String(), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var sb strings.Builder
+		sb.WriteString("map[string]interface{}{")
+		for _, k := range keys {
+			lit, err := rulesGoLiteral(t[k])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(quoteString(k))
+			sb.WriteString(": ")
+			sb.WriteString(lit)
+			sb.WriteString(", ")
+		}
+		sb.WriteString("}")
+		return sb.String(), nil
+	default:
+		return "", fmt.Errorf("rules codegen: unsupported literal type %T", v)
+	}
+}
+
+var rulesFuncMap = func() template.FuncMap {
+	fns := template.FuncMap{
+		"RulesGoParamType": rulesGoParamType,
+		"RulesGoLiteral":   rulesGoLiteral,
+		"RulesHasDefault":  rulesHasDefault,
+	}
+	for k, v := range funcMap {
+		fns[k] = v
+	}
+	return fns
+}()
+
+const rulesTmpl = `
+{{ define "rules resolver" -}}
+// Code generated by aws/endpoints/v3model_rules_codegen.go. DO NOT EDIT.
+
+package {{ .PackageName }}
+
+import (
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+// EndpointParameters are the inputs to ResolveEndpoint for the
+// {{ .ServiceID }} service, derived from the service's endpoint rule set.
+type EndpointParameters struct {
+	{{ range $name, $p := .Parameters -}}
+	{{ StringIfSet "// %s\n" $p.Documentation -}}
+	{{ ToSymbol $name }} {{ RulesGoParamType $p }}
+	{{ end -}}
+}
+
+// AsRuleScope implements endpoints.EndpointParameters. A parameter left
+// unset by the caller falls back to its rule-set default, when the
+// rule-set declares one, rather than being omitted from scope.
+func (p EndpointParameters) AsRuleScope() map[string]interface{} {
+	scope := map[string]interface{}{}
+	{{ range $name, $p := .Parameters -}}
+	if p.{{ ToSymbol $name }} != nil {
+		scope[{{ QuoteString $name }}] = *p.{{ ToSymbol $name }}
+	{{ if RulesHasDefault $p -}}
+	} else {
+		scope[{{ QuoteString $name }}] = {{ RulesGoLiteral $p.Default }}
+	{{ end -}}
+	}
+	{{ end -}}
+	return scope
+}
+
+// ResolveEndpoint resolves an Endpoint for p by walking {{ .ServiceID }}'s
+// endpoint rule tree.
+func (p EndpointParameters) ResolveEndpoint() (endpoints.RuleEndpoint, error) {
+	return endpoints.ResolveEndpoint(ruleTree, p.AsRuleScope())
+}
+
+var ruleTree = {{ template "rules gocode Rules" .Rules }}
+{{- end }}
+
+{{ define "rules gocode Rules" -}}
+[]endpoints.Rule{
+	{{ range $_, $r := . -}}
+	{{ template "rules gocode Rule" $r }},
+	{{ end }}
+}
+{{- end }}
+
+{{ define "rules gocode Rule" -}}
+endpoints.Rule{
+	{{ if .Conditions -}}
+	Conditions: {{ template "rules gocode Conditions" .Conditions }},
+	{{ end -}}
+	Type: {{ QuoteString .Type }},
+	{{ if .Rules -}}
+	Rules: {{ template "rules gocode Rules" .Rules }},
+	{{ end -}}
+	{{ if .Endpoint -}}
+	Endpoint: &endpoints.RuleEndpoint{
+		URL: {{ QuoteString .Endpoint.URL }},
+		{{ if .Endpoint.Properties -}}
+		Properties: {{ RulesGoLiteral .Endpoint.Properties }},
+		{{ end -}}
+		{{ if .Endpoint.Headers -}}
+		Headers: map[string][]string{
+			{{ range $k, $vs := .Endpoint.Headers -}}
+			{{ QuoteString $k }}: {{ RulesGoLiteral $vs }},
+			{{ end }}
+		},
+		{{ end -}}
+	},
+	{{ end -}}
+	{{ StringIfSet "Error: %q,\n" .Error -}}
+}
+{{- end }}
+
+{{ define "rules gocode Conditions" -}}
+[]endpoints.RuleCondition{
+	{{ range $_, $c := . -}}
+	{
+		Fn: {{ QuoteString $c.Fn }},
+		{{ if $c.Argv -}}
+		Argv: []interface{}{ {{ range $_, $a := $c.Argv }}{{ RulesGoLiteral $a }}, {{ end }} },
+		{{ end -}}
+		{{ StringIfSet "Assign: %q,\n" $c.Assign -}}
+	},
+	{{ end }}
+}
+{{- end }}
+`